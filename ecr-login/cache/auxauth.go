@@ -0,0 +1,237 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cache
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// ServiceAuxiliary identifies entries resolved through the auxiliary
+	// auth chain for registries that are not ECR at all.
+	ServiceAuxiliary = "aux"
+
+	auxAuthConfigEnvVar = "AWS_ECR_AUTH_CONFIG"
+	auxAuthConfigFile   = "config.json"
+	auxCachePrefixKey   = "aux-"
+	auxPublicCacheKey   = "aux-public-"
+
+	// auxCacheTTL is intentionally short: auxiliary entries are resolved
+	// from credentials this helper does not own (an external helper binary
+	// or a static file), so they should not be trusted to stay valid for as
+	// long as a directly-issued ECR token.
+	auxCacheTTL = 10 * time.Minute
+)
+
+// ecrHostPattern matches ECR and ECR Public registry hostnames, which are
+// always served by the normal ECR token flow and never the auxiliary chain.
+var ecrHostPattern = regexp.MustCompile(`^\d{12}\.dkr\.ecr(-fips)?\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$|^public\.ecr\.aws$`)
+
+// IsECRRegistry reports whether registry looks like an ECR or ECR Public
+// hostname.
+func IsECRRegistry(registry string) bool {
+	return ecrHostPattern.MatchString(registry)
+}
+
+// AuxiliaryAuthEntry describes one entry in the auxiliary auth chain: for a
+// registry matching RegistryPattern (a path.Match-style glob), credentials
+// are resolved either by invoking the docker-credential-<Helper> binary, or
+// by reading ConfigFile as a containers/image-style auth.json. Exactly one
+// of Helper or ConfigFile is expected to be set.
+type AuxiliaryAuthEntry struct {
+	RegistryPattern string `json:"registryPattern"`
+	Helper          string `json:"helper,omitempty"`
+	ConfigFile      string `json:"configFile,omitempty"`
+}
+
+// AuxiliaryAuthConfig is the on-disk format of ~/.ecr/config.json (or
+// $AWS_ECR_AUTH_CONFIG).
+type AuxiliaryAuthConfig struct {
+	AuxiliaryAuth []AuxiliaryAuthEntry `json:"auxiliaryAuth"`
+	// AuthSoftFail, when true, makes a lookup whose matching entry fails to
+	// resolve (its helper or file errors) return "no credentials" instead of
+	// an error. A lookup that matches no configured entry returns "no
+	// credentials" whether or not AuthSoftFail is set.
+	AuthSoftFail bool `json:"authSoftFail,omitempty"`
+}
+
+// LoadAuxiliaryAuthConfig reads the auxiliary auth configuration from
+// $AWS_ECR_AUTH_CONFIG if set, otherwise from config.json under
+// ecrConfigDir (which may be empty, defaulting to ~/.ecr). A missing file is
+// not an error: it is treated as an empty configuration.
+func LoadAuxiliaryAuthConfig(ecrConfigDir string) (*AuxiliaryAuthConfig, error) {
+	configPath := os.Getenv(auxAuthConfigEnvVar)
+	if configPath == "" {
+		if ecrConfigDir == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, err
+			}
+			ecrConfigDir = filepath.Join(home, cacheDirName)
+		}
+		configPath = filepath.Join(ecrConfigDir, auxAuthConfigFile)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &AuxiliaryAuthConfig{}, nil
+		}
+		return nil, err
+	}
+
+	config := &AuxiliaryAuthConfig{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// AuxiliaryAuthResolver resolves credentials for non-ECR registries through
+// the external credential-helper / static auth.json chain described by an
+// AuxiliaryAuthConfig, short-TTL caching the result in the same cache file
+// fileCredentialCache uses, under a distinct prefix.
+type AuxiliaryAuthResolver struct {
+	config AuxiliaryAuthConfig
+	cache  CredentialsCache
+}
+
+// NewAuxiliaryAuthResolver builds an AuxiliaryAuthResolver that stores
+// resolved entries under cacheDir/filename, alongside the regular ECR cache.
+func NewAuxiliaryAuthResolver(config AuxiliaryAuthConfig, cacheDir string, filename string) *AuxiliaryAuthResolver {
+	return &AuxiliaryAuthResolver{
+		config: config,
+		cache:  NewFileCredentialsCache(cacheDir, filename, auxCachePrefixKey, auxPublicCacheKey, "", ""),
+	}
+}
+
+// Get resolves credentials for registry. It returns a nil entry and a nil
+// error when no configured auxiliary entry matches registry, or when one
+// matches but fails to resolve and AuthSoftFail is set.
+func (resolver *AuxiliaryAuthResolver) Get(registry string) (*AuthEntry, error) {
+	if IsECRRegistry(registry) {
+		return nil, nil
+	}
+
+	if cached := resolver.cache.Get(registry); cached != nil && cached.IsValid(time.Now()) {
+		return cached, nil
+	}
+
+	aux, ok := resolver.match(registry)
+	if !ok {
+		return nil, nil
+	}
+
+	entry, err := resolver.resolve(aux, registry)
+	if err != nil {
+		if resolver.config.AuthSoftFail {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	resolver.cache.Set(registry, entry)
+	return entry, nil
+}
+
+func (resolver *AuxiliaryAuthResolver) match(registry string) (AuxiliaryAuthEntry, bool) {
+	for _, aux := range resolver.config.AuxiliaryAuth {
+		if matched, err := path.Match(aux.RegistryPattern, registry); err == nil && matched {
+			return aux, true
+		}
+	}
+	return AuxiliaryAuthEntry{}, false
+}
+
+func (resolver *AuxiliaryAuthResolver) resolve(aux AuxiliaryAuthEntry, registry string) (*AuthEntry, error) {
+	if aux.Helper != "" {
+		return resolveViaCredentialHelper(aux.Helper, registry)
+	}
+	return resolveViaAuthFile(aux.ConfigFile, registry)
+}
+
+// dockerCredentialHelperOutput is the JSON a `docker-credential-<helper> get`
+// process writes to stdout, per the Docker credential-helper protocol.
+type dockerCredentialHelperOutput struct {
+	ServerURL string `json:"ServerURL"`
+	Username  string `json:"Username"`
+	Secret    string `json:"Secret"`
+}
+
+func resolveViaCredentialHelper(helper string, registry string) (*AuthEntry, error) {
+	cmd := exec.Command(fmt.Sprintf("docker-credential-%s", helper), "get")
+	cmd.Stdin = strings.NewReader(registry)
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("docker-credential-%s get %s: %w", helper, registry, err)
+	}
+
+	output := &dockerCredentialHelperOutput{}
+	if err := json.Unmarshal(stdout.Bytes(), output); err != nil {
+		return nil, fmt.Errorf("docker-credential-%s get %s: %w", helper, registry, err)
+	}
+
+	return newAuxAuthEntry(registry, base64.StdEncoding.EncodeToString([]byte(output.Username+":"+output.Secret))), nil
+}
+
+// authConfigFile is the containers/image auth.json format:
+// {"auths": {"registry": {"auth": "base64(user:pass)"}}}.
+type authConfigFile struct {
+	Auths map[string]struct {
+		Auth string `json:"auth"`
+	} `json:"auths"`
+}
+
+func resolveViaAuthFile(configFile string, registry string) (*AuthEntry, error) {
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := &authConfigFile{}
+	if err := json.Unmarshal(data, parsed); err != nil {
+		return nil, err
+	}
+
+	auth, ok := parsed.Auths[registry]
+	if !ok {
+		return nil, fmt.Errorf("no auth entry for %s in %s", registry, configFile)
+	}
+
+	return newAuxAuthEntry(registry, auth.Auth), nil
+}
+
+func newAuxAuthEntry(registry string, basicAuth string) *AuthEntry {
+	now := time.Now()
+	return &AuthEntry{
+		AuthorizationToken: basicAuth,
+		RequestedAt:        now,
+		ExpiresAt:          now.Add(auxCacheTTL),
+		ProxyEndpoint:      registry,
+		Service:            ServiceAuxiliary,
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cache
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testAuxRegistry = "registry.example.com"
+
+func TestIsECRRegistry(t *testing.T) {
+	assert.True(t, IsECRRegistry("123456789012.dkr.ecr.us-west-2.amazonaws.com"))
+	assert.True(t, IsECRRegistry("123456789012.dkr.ecr-fips.us-west-2.amazonaws.com"))
+	assert.True(t, IsECRRegistry("public.ecr.aws"))
+	assert.False(t, IsECRRegistry(testAuxRegistry))
+}
+
+func TestLoadAuxiliaryAuthConfigMissingFileIsEmpty(t *testing.T) {
+	config, err := LoadAuxiliaryAuthConfig(t.TempDir())
+	assert.NoError(t, err)
+	assert.Empty(t, config.AuxiliaryAuth)
+}
+
+func TestLoadAuxiliaryAuthConfigFromEnvVar(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "auth-config.json")
+	assert.NoError(t, os.WriteFile(configFile, []byte(`{
+		"authSoftFail": true,
+		"auxiliaryAuth": [{"registryPattern": "*.example.com", "helper": "example"}]
+	}`), 0600))
+
+	os.Setenv(auxAuthConfigEnvVar, configFile)
+	defer os.Unsetenv(auxAuthConfigEnvVar)
+
+	config, err := LoadAuxiliaryAuthConfig("")
+	assert.NoError(t, err)
+	assert.True(t, config.AuthSoftFail)
+	assert.Len(t, config.AuxiliaryAuth, 1)
+	assert.Equal(t, "example", config.AuxiliaryAuth[0].Helper)
+}
+
+func TestAuxiliaryAuthResolverSkipsECRRegistries(t *testing.T) {
+	resolver := NewAuxiliaryAuthResolver(AuxiliaryAuthConfig{
+		AuxiliaryAuth: []AuxiliaryAuthEntry{{RegistryPattern: "*", ConfigFile: "/does/not/matter"}},
+	}, t.TempDir(), testFilename)
+
+	entry, err := resolver.Get("public.ecr.aws")
+	assert.NoError(t, err)
+	assert.Nil(t, entry)
+}
+
+func TestAuxiliaryAuthResolverNoMatchReturnsNil(t *testing.T) {
+	resolver := NewAuxiliaryAuthResolver(AuxiliaryAuthConfig{
+		AuxiliaryAuth: []AuxiliaryAuthEntry{{RegistryPattern: "*.other.com", ConfigFile: "/does/not/matter"}},
+	}, t.TempDir(), testFilename)
+
+	entry, err := resolver.Get(testAuxRegistry)
+	assert.NoError(t, err)
+	assert.Nil(t, entry)
+}
+
+func TestAuxiliaryAuthResolverResolvesFromAuthFileAndCaches(t *testing.T) {
+	dir := t.TempDir()
+	authFile := filepath.Join(dir, "auth.json")
+	expectedAuth := base64.StdEncoding.EncodeToString([]byte("user:pass"))
+	assert.NoError(t, os.WriteFile(authFile, []byte(`{"auths": {"`+testAuxRegistry+`": {"auth": "`+expectedAuth+`"}}}`), 0600))
+
+	resolver := NewAuxiliaryAuthResolver(AuxiliaryAuthConfig{
+		AuxiliaryAuth: []AuxiliaryAuthEntry{{RegistryPattern: "*.example.com", ConfigFile: authFile}},
+	}, dir, testFilename)
+
+	entry, err := resolver.Get(testAuxRegistry)
+	assert.NoError(t, err)
+	assert.NotNil(t, entry)
+	assert.Equal(t, expectedAuth, entry.AuthorizationToken)
+	assert.Equal(t, ServiceAuxiliary, entry.Service)
+
+	// The resolved entry should now be served from the cache without
+	// touching the auth file again.
+	assert.NoError(t, os.Remove(authFile))
+	cachedEntry, err := resolver.Get(testAuxRegistry)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedAuth, cachedEntry.AuthorizationToken)
+}
+
+func TestAuxiliaryAuthResolverMissingAuthFileSoftFails(t *testing.T) {
+	resolver := NewAuxiliaryAuthResolver(AuxiliaryAuthConfig{
+		AuthSoftFail:  true,
+		AuxiliaryAuth: []AuxiliaryAuthEntry{{RegistryPattern: "*.example.com", ConfigFile: "/does/not/exist.json"}},
+	}, t.TempDir(), testFilename)
+
+	entry, err := resolver.Get(testAuxRegistry)
+	assert.NoError(t, err)
+	assert.Nil(t, entry)
+}
+
+func TestAuxiliaryAuthResolverMissingAuthFileErrorsWithoutSoftFail(t *testing.T) {
+	resolver := NewAuxiliaryAuthResolver(AuxiliaryAuthConfig{
+		AuthSoftFail:  false,
+		AuxiliaryAuth: []AuxiliaryAuthEntry{{RegistryPattern: "*.example.com", ConfigFile: "/does/not/exist.json"}},
+	}, t.TempDir(), testFilename)
+
+	entry, err := resolver.Get(testAuxRegistry)
+	assert.Error(t, err)
+	assert.Nil(t, entry)
+}
@@ -0,0 +1,191 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+const (
+	cacheFilename            = "cache.json"
+	cacheDirName             = ".ecr"
+	disableCacheEnvVar       = "AWS_ECR_DISABLE_CACHE"
+	cacheBackendEnvVar       = "CACHE_BACKEND"
+	defaultCacheBackend      = "file"
+	migrateLegacyCacheEnvVar = "AWS_ECR_MIGRATE_LEGACY_CACHE"
+)
+
+// BackendFactory builds a CredentialsCache for config, rooted at cacheDir
+// (a backend for which the notion of a directory makes no sense, such as
+// memory, is free to ignore it).
+type BackendFactory func(config aws.Config, cacheDir string) CredentialsCache
+
+var backends = map[string]BackendFactory{}
+
+// RegisterBackend makes a CredentialsCache implementation selectable via the
+// CACHE_BACKEND environment variable under name. It is expected to be called
+// from package init functions; registering the same name twice replaces the
+// previous factory.
+func RegisterBackend(name string, factory BackendFactory) {
+	backends[name] = factory
+}
+
+func init() {
+	RegisterBackend("file", newFileCacheBackend)
+	RegisterBackend("memory", newMemoryCacheBackend)
+	RegisterBackend("keyring", newKeyringCacheBackend)
+	RegisterBackend("null", func(config aws.Config, cacheDir string) CredentialsCache {
+		return &nullCredentialsCache{}
+	})
+}
+
+// BuildCredentialsCache builds the CredentialsCache to use for config and
+// cacheDir, selecting a backend via the CACHE_BACKEND environment variable
+// (file, memory, keyring, or null; file is the default). cacheDir is passed
+// through to the selected backend's factory. If caching has been disabled
+// via AWS_ECR_DISABLE_CACHE, a no-op cache is returned instead regardless of
+// CACHE_BACKEND. If AWS_ECR_MIGRATE_LEGACY_CACHE is set and the process is
+// not running in FIPS mode, the cache's legacy entries are migrated to the
+// current key scheme once before being returned.
+func BuildCredentialsCache(config aws.Config, cacheDir string) CredentialsCache {
+	if os.Getenv(disableCacheEnvVar) != "" {
+		return &nullCredentialsCache{}
+	}
+
+	backendName := os.Getenv(cacheBackendEnvVar)
+	if backendName == "" {
+		backendName = defaultCacheBackend
+	}
+
+	factory, ok := backends[backendName]
+	if !ok {
+		factory = backends[defaultCacheBackend]
+	}
+
+	cache := factory(config, cacheDir)
+
+	if os.Getenv(migrateLegacyCacheEnvVar) != "" && !isFipsMode() {
+		cache.Migrate()
+	}
+
+	return cache
+}
+
+// cacheKeySet is the set of namespacing keys shared by every CredentialsCache
+// backend, derived from the region and credentials used to build the cache.
+type cacheKeySet struct {
+	cachePrefixKey       string
+	publicCacheKey       string
+	legacyCachePrefixKey string
+	legacyPublicCacheKey string
+}
+
+// resolveCacheKeys retrieves config's credentials and, if they are usable,
+// computes the cacheKeySet for them. ok is false if the cache should not be
+// used at all (e.g. anonymous credentials).
+func resolveCacheKeys(config aws.Config) (keys cacheKeySet, creds aws.Credentials, ok bool) {
+	creds, err := config.Credentials.Retrieve(context.TODO())
+	if err != nil || creds.AccessKeyID == "" {
+		return cacheKeySet{}, aws.Credentials{}, false
+	}
+
+	keys = cacheKeySet{
+		cachePrefixKey: credentialsCachePrefix(config.Region, creds),
+		publicCacheKey: fmt.Sprintf("%s-%s", ServiceECRPublic, credentialsHash(config.Region, creds)),
+	}
+	if !isFipsMode() {
+		keys.legacyCachePrefixKey = legacyCredentialsCachePrefix(config.Region, creds)
+		keys.legacyPublicCacheKey = fmt.Sprintf("%s-%s", ServiceECRPublic, legacyCredentialsHash(creds))
+	}
+
+	return keys, creds, true
+}
+
+func newFileCacheBackend(config aws.Config, cacheDir string) CredentialsCache {
+	keys, _, ok := resolveCacheKeys(config)
+	if !ok {
+		return &nullCredentialsCache{}
+	}
+
+	if cacheDir == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			cacheDir = filepath.Join(home, cacheDirName)
+		}
+	}
+
+	return NewFileCredentialsCache(cacheDir, cacheFilename, keys.cachePrefixKey, keys.publicCacheKey, keys.legacyCachePrefixKey, keys.legacyPublicCacheKey)
+}
+
+// credentialsCachePrefix returns the cache key prefix for region and creds.
+// The hash folds in the session token (in addition to the access key) so
+// that a single access key assuming multiple roles - each with its own
+// session token - does not collide on a single cache entry.
+func credentialsCachePrefix(region string, creds aws.Credentials) string {
+	return fmt.Sprintf("%s-%s-", region, credentialsHash(region, creds))
+}
+
+func credentialsHash(region string, creds aws.Credentials) string {
+	hash := sha256.Sum256([]byte(strings.Join([]string{region, creds.AccessKeyID, creds.SessionToken}, "|")))
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// legacyCredentialsCachePrefix reproduces the pre-SHA256 cache key prefix
+// (MD5 of the access key only), kept around as a lookup fallback and as the
+// source key for Migrate, so upgrading the helper does not strand
+// already-cached entries. New entries are never written under it.
+func legacyCredentialsCachePrefix(region string, creds aws.Credentials) string {
+	return fmt.Sprintf("%s-%s-", region, legacyCredentialsHash(creds))
+}
+
+func legacyCredentialsHash(creds aws.Credentials) string {
+	hash := md5.Sum([]byte(creds.AccessKeyID))
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// isFipsMode reports whether the process is running with BoringCrypto/FIPS
+// mode enabled via GODEBUG=fips140=on|only, in which case MD5-backed legacy
+// cache lookups must be skipped entirely.
+func isFipsMode() bool {
+	for _, setting := range strings.Split(os.Getenv("GODEBUG"), ",") {
+		if setting == "fips140=on" || setting == "fips140=only" {
+			return true
+		}
+	}
+	return false
+}
+
+// nullCredentialsCache is a CredentialsCache that never stores anything,
+// used when on-disk caching is unavailable or has been disabled.
+type nullCredentialsCache struct{}
+
+func (cache *nullCredentialsCache) Get(registry string) *AuthEntry { return nil }
+
+func (cache *nullCredentialsCache) Set(registry string, entry *AuthEntry) {}
+
+func (cache *nullCredentialsCache) GetPublic() *AuthEntry { return nil }
+
+func (cache *nullCredentialsCache) Clear() {}
+
+func (cache *nullCredentialsCache) List() []*AuthEntry { return nil }
+
+func (cache *nullCredentialsCache) Migrate() error { return nil }
@@ -29,8 +29,11 @@ const (
 	testAccessKey     = "accessKey"
 	testSecretKey     = "secretKey"
 	testToken         = "token"
-	// base64 SHA-256 sum of "accessKey" - FIPS-compatible
-	testCredentialHash = "xOV45/s/9aT8cbO8tDicjEV1KKkfpLdKrQs0ipPGgGc="
+	testOtherToken    = "other-token"
+	// base64 SHA-256 sum of "test-region|accessKey|token" - FIPS-compatible
+	testCredentialHash = "5CveeXR2ELbNcZCopcZgyK8CMWgTCrIzy/Zs+MJrTg4="
+	// base64 SHA-256 sum of "test-region|accessKey|" (no session token) - FIPS-compatible
+	testCredentialHashNoToken = "4I0y39CDMh74BBulIhfBrQmvxydMbZ/ddJT/39F+E00="
 	// Legacy base64 MD5 sum of "accessKey" for backward compatibility tests
 	testLegacyCredentialHash = "YWNjZXNzS2V51B2M2Y8AsgTpgAmY7PhCfg=="
 )
@@ -76,15 +79,86 @@ func TestFactoryBuildNullCache(t *testing.T) {
 	assert.True(t, ok, "built cache is a nullCredentialsCache")
 }
 
+func TestFactoryBuildMemoryCacheBackend(t *testing.T) {
+	os.Setenv(cacheBackendEnvVar, "memory")
+	defer os.Unsetenv(cacheBackendEnvVar)
+
+	config := aws.Config{
+		Region:      testRegion,
+		Credentials: credentials.NewStaticCredentialsProvider(testAccessKey, testSecretKey, testToken),
+	}
+
+	cache := BuildCredentialsCache(config, "")
+	_, ok := cache.(*memoryCredentialCache)
+	assert.True(t, ok, "CACHE_BACKEND=memory should build a memoryCredentialCache")
+}
+
+func TestFactoryBuildKeyringCacheBackendFallsBackOnOpenFailure(t *testing.T) {
+	os.Setenv(cacheBackendEnvVar, "keyring")
+	defer os.Unsetenv(cacheBackendEnvVar)
+
+	config := aws.Config{
+		Region:      testRegion,
+		Credentials: credentials.NewStaticCredentialsProvider(testAccessKey, testSecretKey, testToken),
+	}
+
+	// The test environment has no usable OS keyring backend available, so
+	// this just exercises the fallback-to-null path rather than panicking.
+	cache := BuildCredentialsCache(config, "")
+	assert.NotNil(t, cache)
+}
+
+func TestFactoryUnknownBackendFallsBackToFile(t *testing.T) {
+	os.Setenv(cacheBackendEnvVar, "not-a-real-backend")
+	defer os.Unsetenv(cacheBackendEnvVar)
+
+	config := aws.Config{
+		Region:      testRegion,
+		Credentials: credentials.NewStaticCredentialsProvider(testAccessKey, testSecretKey, testToken),
+	}
+
+	cache := BuildCredentialsCache(config, "")
+	_, ok := cache.(*fileCredentialCache)
+	assert.True(t, ok, "unknown CACHE_BACKEND should fall back to the file backend")
+}
+
+func TestRegisterBackend(t *testing.T) {
+	RegisterBackend("test-backend", func(config aws.Config, cacheDir string) CredentialsCache {
+		return &nullCredentialsCache{}
+	})
+	defer delete(backends, "test-backend")
+
+	os.Setenv(cacheBackendEnvVar, "test-backend")
+	defer os.Unsetenv(cacheBackendEnvVar)
+
+	cache := BuildCredentialsCache(aws.Config{Region: testRegion}, "")
+	_, ok := cache.(*nullCredentialsCache)
+	assert.True(t, ok, "RegisterBackend should make a custom backend selectable via CACHE_BACKEND")
+}
+
 // TestCredentialsPrefixUsesNewHash verifies that credentialsCachePrefix uses SHA-256
 func TestCredentialsPrefixUsesNewHash(t *testing.T) {
 	creds := aws.Credentials{AccessKeyID: testAccessKey}
 	prefix := credentialsCachePrefix(testRegion, creds)
-	expectedPrefix := fmt.Sprintf("%s-%s-", testRegion, testCredentialHash)
+	expectedPrefix := fmt.Sprintf("%s-%s-", testRegion, testCredentialHashNoToken)
 
 	assert.Equal(t, expectedPrefix, prefix, "Cache prefix should use FIPS-compatible SHA-256 hash")
 }
 
+// TestCredentialsPrefixFoldsInSessionToken verifies that a single access key
+// used to assume two different roles (each with its own session token) gets
+// two distinct cache prefixes, so their ECR tokens cannot collide.
+func TestCredentialsPrefixFoldsInSessionToken(t *testing.T) {
+	credsRoleA := aws.Credentials{AccessKeyID: testAccessKey, SessionToken: testToken}
+	credsRoleB := aws.Credentials{AccessKeyID: testAccessKey, SessionToken: testOtherToken}
+
+	prefixA := credentialsCachePrefix(testRegion, credsRoleA)
+	prefixB := credentialsCachePrefix(testRegion, credsRoleB)
+
+	assert.NotEqual(t, prefixA, prefixB, "credentials sharing an access key but differing in session token must not collide")
+	assert.Equal(t, fmt.Sprintf("%s-%s-", testRegion, testCredentialHash), prefixA)
+}
+
 // TestIsFipsMode verifies that the isFipsMode function correctly detects FIPS mode
 func TestIsFipsMode(t *testing.T) {
 	tests := []struct {
@@ -145,3 +219,58 @@ func TestLegacyKeysNotGeneratedInFipsSimulation(t *testing.T) {
 	assert.NotEmpty(t, fileCache.legacyCachePrefixKey, "Legacy cache prefix should be present in non-FIPS mode")
 	assert.NotEmpty(t, fileCache.legacyPublicCacheKey, "Legacy public cache key should be present in non-FIPS mode")
 }
+
+// TestBuildCredentialsCacheMigratesLegacyEntriesWhenOptedIn verifies that
+// BuildCredentialsCache runs a migration pass when AWS_ECR_MIGRATE_LEGACY_CACHE
+// is set, rewriting legacy entries already on disk.
+func TestBuildCredentialsCacheMigratesLegacyEntriesWhenOptedIn(t *testing.T) {
+	os.Setenv(migrateLegacyCacheEnvVar, "1")
+	defer os.Unsetenv(migrateLegacyCacheEnvVar)
+
+	cacheDir := t.TempDir()
+	config := aws.Config{
+		Region:      testRegion,
+		Credentials: credentials.NewStaticCredentialsProvider(testAccessKey, testSecretKey, testToken),
+	}
+
+	fileCache := BuildCredentialsCache(config, cacheDir).(*fileCredentialCache)
+
+	registryCache := newRegistryCache()
+	legacyKey := fileCache.legacyCachePrefixKey + testRegistryName
+	registryCache.Registries[legacyKey] = &testAuthEntry
+	assert.NoError(t, fileCache.save(registryCache))
+
+	// Building again against the same directory/identity should trigger the
+	// opt-in migration pass before handing the cache back.
+	fileCache = BuildCredentialsCache(config, cacheDir).(*fileCredentialCache)
+
+	loaded, err := fileCache.load()
+	assert.NoError(t, err)
+	assert.Nil(t, loaded.Registries[legacyKey], "legacy entry should have been migrated")
+}
+
+// TestBuildCredentialsCacheDoesNotMigrateByDefault verifies the opt-in
+// nature of AWS_ECR_MIGRATE_LEGACY_CACHE: without it, legacy entries are
+// left alone.
+func TestBuildCredentialsCacheDoesNotMigrateByDefault(t *testing.T) {
+	os.Unsetenv(migrateLegacyCacheEnvVar)
+
+	cacheDir := t.TempDir()
+	config := aws.Config{
+		Region:      testRegion,
+		Credentials: credentials.NewStaticCredentialsProvider(testAccessKey, testSecretKey, testToken),
+	}
+
+	fileCache := BuildCredentialsCache(config, cacheDir).(*fileCredentialCache)
+
+	registryCache := newRegistryCache()
+	legacyKey := fileCache.legacyCachePrefixKey + testRegistryName
+	registryCache.Registries[legacyKey] = &testAuthEntry
+	assert.NoError(t, fileCache.save(registryCache))
+
+	fileCache = BuildCredentialsCache(config, cacheDir).(*fileCredentialCache)
+
+	loaded, err := fileCache.load()
+	assert.NoError(t, err)
+	assert.NotNil(t, loaded.Registries[legacyKey], "legacy entry should be untouched without AWS_ECR_MIGRATE_LEGACY_CACHE")
+}
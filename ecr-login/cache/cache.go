@@ -0,0 +1,86 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package cache implements a cache of ECR authorization tokens (on disk by
+// default, or in memory or the OS keyring) so that repeated credential
+// helper invocations do not need to call the ECR GetAuthorizationToken API
+// on every docker pull/push.
+package cache
+
+import "time"
+
+const (
+	// ServiceECR identifies authorization tokens for the standard (private) ECR service.
+	ServiceECR = "ecr"
+	// ServiceECRPublic identifies authorization tokens for ECR Public.
+	ServiceECRPublic = "ecr-public"
+
+	// cacheVersion is bumped whenever the on-disk registryCache format changes
+	// in a way that makes older entries unreadable; a mismatch causes the
+	// cache to be treated as empty rather than erroring out.
+	cacheVersion = "1.0"
+)
+
+// AuthEntry is a single cached ECR authorization token along with the
+// bookkeeping needed to know whether it is still usable.
+type AuthEntry struct {
+	AuthorizationToken string
+	RequestedAt        time.Time
+	ExpiresAt          time.Time
+	ProxyEndpoint      string
+	Service            string
+}
+
+// IsValid reports whether the entry has not yet expired as of now.
+func (authEntry *AuthEntry) IsValid(now time.Time) bool {
+	return now.Before(authEntry.ExpiresAt)
+}
+
+// CredentialsCache is a cache of ECR authorization tokens, scoped to the AWS
+// credentials and region used to request them. Implementations must be safe
+// to use from a single credential helper invocation; they are not expected
+// to be shared across goroutines.
+type CredentialsCache interface {
+	// Get returns the cached entry for the given ECR registry, or nil if
+	// there is none. The entry may have expired; callers check IsValid.
+	Get(registry string) *AuthEntry
+	// Set stores entry under registry. Entries with Service ==
+	// ServiceECRPublic are stored independently of the registry name, since
+	// ECR Public exposes a single registry per account.
+	Set(registry string, entry *AuthEntry)
+	// GetPublic returns the cached ECR Public entry, or nil if there is none.
+	GetPublic() *AuthEntry
+	// Clear removes all cached entries.
+	Clear()
+	// List returns every entry currently in the cache, in no particular order.
+	List() []*AuthEntry
+	// Migrate rewrites any entries stored under a legacy cache key to the
+	// current key scheme and removes the legacy entries, so that lookups no
+	// longer need to fall back to them. Only the file backend rewrites
+	// entries; the other implementations are no-ops that leave any legacy
+	// entries in place.
+	Migrate() error
+}
+
+// registryCache is the on-disk representation of a CredentialsCache.
+type registryCache struct {
+	Version    string
+	Registries map[string]*AuthEntry
+}
+
+func newRegistryCache() *registryCache {
+	return &registryCache{
+		Version:    cacheVersion,
+		Registries: make(map[string]*AuthEntry),
+	}
+}
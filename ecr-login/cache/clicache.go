@@ -0,0 +1,147 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+const (
+	// shareCLICacheEnvVar opts the helper into reading/writing the AWS CLI's
+	// STS credential cache so that `aws sso login`/`aws configure sso`
+	// sessions can be reused instead of triggering another MFA/SSO prompt.
+	shareCLICacheEnvVar = "AWS_ECR_SHARE_CLI_CACHE"
+	cliCacheDirName     = ".aws/cli/cache"
+)
+
+// cliCacheEntry is the subset of the AWS CLI's JSON STS credential cache
+// format (~/.aws/cli/cache/<sha1-of-params>.json) that the helper needs.
+type cliCacheEntry struct {
+	Credentials struct {
+		AccessKeyID     string    `json:"AccessKeyId"`
+		SecretAccessKey string    `json:"SecretAccessKey"`
+		SessionToken    string    `json:"SessionToken"`
+		Expiration      time.Time `json:"Expiration"`
+	} `json:"Credentials"`
+}
+
+// sharedCLICredentialsProvider is an aws.CredentialsProvider that serves
+// credentials out of the AWS CLI's on-disk STS cache, falling back to next
+// (and populating the cache for future lookups) on a miss.
+type sharedCLICredentialsProvider struct {
+	cacheDir string
+	cacheKey string
+	next     aws.CredentialsProvider
+}
+
+// BuildSharedCLICredentialsProvider wraps next so that credentials are first
+// looked up in the AWS CLI's ~/.aws/cli/cache STS cache, only calling next -
+// and populating the CLI cache - on a miss. cacheKey must be the same input
+// botocore's CredentialResolver._create_cache_key hashes to name its cache
+// file: the assume-role call parameters JSON-serialized with sorted keys
+// (not an already-computed digest - this provider does the SHA-1 itself, the
+// same way the CLI does, so the two land on the same filename). cliCacheDir
+// may be empty to default to ~/.aws/cli/cache. This is opt-in via
+// AWS_ECR_SHARE_CLI_CACHE=1, since it means the helper reuses-and-writes a
+// cache owned by another tool.
+func BuildSharedCLICredentialsProvider(config aws.Config, next aws.CredentialsProvider, cacheKey string, cliCacheDir string) aws.CredentialsProvider {
+	if os.Getenv(shareCLICacheEnvVar) == "" {
+		return next
+	}
+
+	if cliCacheDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return next
+		}
+		cliCacheDir = filepath.Join(home, cliCacheDirName)
+	}
+
+	return &sharedCLICredentialsProvider{
+		cacheDir: cliCacheDir,
+		cacheKey: cacheKey,
+		next:     next,
+	}
+}
+
+func (p *sharedCLICredentialsProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	if entry, ok := p.load(); ok {
+		return aws.Credentials{
+			AccessKeyID:     entry.Credentials.AccessKeyID,
+			SecretAccessKey: entry.Credentials.SecretAccessKey,
+			SessionToken:    entry.Credentials.SessionToken,
+			CanExpire:       true,
+			Expires:         entry.Credentials.Expiration,
+		}, nil
+	}
+
+	creds, err := p.next.Retrieve(ctx)
+	if err != nil {
+		return aws.Credentials{}, err
+	}
+
+	p.save(creds)
+
+	return creds, nil
+}
+
+func (p *sharedCLICredentialsProvider) cacheFile() string {
+	sum := sha1.Sum([]byte(p.cacheKey))
+	return filepath.Join(p.cacheDir, fmt.Sprintf("%x.json", sum))
+}
+
+func (p *sharedCLICredentialsProvider) load() (*cliCacheEntry, bool) {
+	data, err := os.ReadFile(p.cacheFile())
+	if err != nil {
+		return nil, false
+	}
+
+	entry := &cliCacheEntry{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		return nil, false
+	}
+
+	if entry.Credentials.AccessKeyID == "" || !time.Now().Before(entry.Credentials.Expiration) {
+		return nil, false
+	}
+
+	return entry, true
+}
+
+func (p *sharedCLICredentialsProvider) save(creds aws.Credentials) {
+	entry := &cliCacheEntry{}
+	entry.Credentials.AccessKeyID = creds.AccessKeyID
+	entry.Credentials.SecretAccessKey = creds.SecretAccessKey
+	entry.Credentials.SessionToken = creds.SessionToken
+	entry.Credentials.Expiration = creds.Expires
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(p.cacheDir, 0700); err != nil {
+		return
+	}
+
+	os.WriteFile(p.cacheFile(), data, 0600)
+}
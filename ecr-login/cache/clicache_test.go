@@ -0,0 +1,145 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/stretchr/testify/assert"
+)
+
+const testCLICacheKey = "arn:aws:iam::123456789012:role/example|session-name"
+
+func TestBuildSharedCLICredentialsProviderDisabledByDefault(t *testing.T) {
+	os.Unsetenv(shareCLICacheEnvVar)
+
+	next := credentials.NewStaticCredentialsProvider(testAccessKey, testSecretKey, testToken)
+	provider := BuildSharedCLICredentialsProvider(aws.Config{}, next, testCLICacheKey, t.TempDir())
+
+	_, ok := provider.(*sharedCLICredentialsProvider)
+	assert.False(t, ok, "should not wrap next unless AWS_ECR_SHARE_CLI_CACHE is set")
+}
+
+func TestSharedCLICredentialsProviderMissFallsBackAndPopulatesCache(t *testing.T) {
+	os.Setenv(shareCLICacheEnvVar, "1")
+	defer os.Unsetenv(shareCLICacheEnvVar)
+
+	cacheDir := t.TempDir()
+	next := credentials.NewStaticCredentialsProvider(testAccessKey, testSecretKey, testToken)
+	provider := BuildSharedCLICredentialsProvider(aws.Config{}, next, testCLICacheKey, cacheDir)
+
+	creds, err := provider.Retrieve(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, testAccessKey, creds.AccessKeyID)
+
+	cliProvider, ok := provider.(*sharedCLICredentialsProvider)
+	assert.True(t, ok)
+	assert.FileExists(t, cliProvider.cacheFile())
+}
+
+func TestSharedCLICredentialsProviderHitSkipsNext(t *testing.T) {
+	cacheDir := t.TempDir()
+	provider := &sharedCLICredentialsProvider{
+		cacheDir: cacheDir,
+		cacheKey: testCLICacheKey,
+		next:     erroringCredentialsProvider{},
+	}
+
+	entry := &cliCacheEntry{}
+	entry.Credentials.AccessKeyID = testAccessKey
+	entry.Credentials.SecretAccessKey = testSecretKey
+	entry.Credentials.SessionToken = testToken
+	entry.Credentials.Expiration = time.Now().Add(time.Hour)
+	writeCLICacheEntry(t, provider.cacheFile(), entry)
+
+	creds, err := provider.Retrieve(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, testAccessKey, creds.AccessKeyID)
+	assert.Equal(t, testToken, creds.SessionToken)
+}
+
+func TestSharedCLICredentialsProviderExpiredEntryFallsThrough(t *testing.T) {
+	cacheDir := t.TempDir()
+	next := credentials.NewStaticCredentialsProvider(testAccessKey, testSecretKey, testToken)
+	provider := &sharedCLICredentialsProvider{
+		cacheDir: cacheDir,
+		cacheKey: testCLICacheKey,
+		next:     next,
+	}
+
+	entry := &cliCacheEntry{}
+	entry.Credentials.AccessKeyID = "expired-access-key"
+	entry.Credentials.Expiration = time.Now().Add(-time.Hour)
+	writeCLICacheEntry(t, provider.cacheFile(), entry)
+
+	creds, err := provider.Retrieve(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, testAccessKey, creds.AccessKeyID, "expired cache entry should fall through to next")
+}
+
+// TestSharedCLICredentialsProviderInteropsWithRealCLICacheFilename guards
+// against the provider's hashing drifting out of step with how the AWS CLI
+// itself names cache files (SHA-1 hex digest of the sorted-key assume-role
+// parameters JSON). It computes that filename independently of cacheFile()
+// and writes the entry there, so a regression that double-hashes cacheKey -
+// or otherwise changes cacheFile()'s output - would make this test fail even
+// though TestSharedCLICredentialsProviderHitSkipsNext (which derives its
+// path from cacheFile() itself) would not catch it.
+func TestSharedCLICredentialsProviderInteropsWithRealCLICacheFilename(t *testing.T) {
+	os.Setenv(shareCLICacheEnvVar, "1")
+	defer os.Unsetenv(shareCLICacheEnvVar)
+
+	cacheDir := t.TempDir()
+	cliCacheKey := `{"AWSAccessKeyId": null, "RoleArn": "arn:aws:iam::123456789012:role/example", "RoleSessionName": "session-name"}`
+	sum := sha1.Sum([]byte(cliCacheKey))
+	realCLIPath := filepath.Join(cacheDir, fmt.Sprintf("%x.json", sum))
+
+	entry := &cliCacheEntry{}
+	entry.Credentials.AccessKeyID = testAccessKey
+	entry.Credentials.SecretAccessKey = testSecretKey
+	entry.Credentials.SessionToken = testToken
+	entry.Credentials.Expiration = time.Now().Add(time.Hour)
+	writeCLICacheEntry(t, realCLIPath, entry)
+
+	provider := BuildSharedCLICredentialsProvider(aws.Config{}, erroringCredentialsProvider{}, cliCacheKey, cacheDir)
+
+	creds, err := provider.Retrieve(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, testAccessKey, creds.AccessKeyID)
+	assert.Equal(t, testToken, creds.SessionToken)
+}
+
+func writeCLICacheEntry(t *testing.T, path string, entry *cliCacheEntry) {
+	t.Helper()
+
+	data, err := json.Marshal(entry)
+	assert.NoError(t, err)
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+	assert.NoError(t, os.WriteFile(path, data, 0600))
+}
+
+type erroringCredentialsProvider struct{}
+
+func (erroringCredentialsProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	panic("next should not be called when the CLI cache has a hit")
+}
@@ -0,0 +1,243 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cache
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fileCredentialCache is a CredentialsCache backed by a single JSON file on
+// disk. Entries are namespaced by cachePrefixKey/publicCacheKey, which are
+// derived from the region and credentials used to build the cache so that
+// different identities do not collide within the same file. legacyCachePrefixKey
+// and legacyPublicCacheKey, when non-empty, are consulted as a lookup
+// fallback so that entries written by older versions of the helper are not
+// silently dropped; new entries are never written under them, and Migrate
+// moves entries found under them to the current keys.
+type fileCredentialCache struct {
+	path                 string
+	filename             string
+	cachePrefixKey       string
+	publicCacheKey       string
+	legacyCachePrefixKey string
+	legacyPublicCacheKey string
+}
+
+// NewFileCredentialsCache creates a CredentialsCache rooted at cacheDir/filename.
+func NewFileCredentialsCache(cacheDir string, filename string, cachePrefixKey string, publicCacheKey string, legacyCachePrefixKey string, legacyPublicCacheKey string) CredentialsCache {
+	return &fileCredentialCache{
+		path:                 cacheDir,
+		filename:             filename,
+		cachePrefixKey:       cachePrefixKey,
+		publicCacheKey:       publicCacheKey,
+		legacyCachePrefixKey: legacyCachePrefixKey,
+		legacyPublicCacheKey: legacyPublicCacheKey,
+	}
+}
+
+func (cache *fileCredentialCache) Get(registry string) *AuthEntry {
+	registryCache, err := cache.load()
+	if err != nil {
+		return nil
+	}
+
+	if entry, ok := registryCache.Registries[cache.cachePrefixKey+registry]; ok {
+		return entry
+	}
+
+	if !isFipsMode() && cache.legacyCachePrefixKey != "" {
+		if entry, ok := registryCache.Registries[cache.legacyCachePrefixKey+registry]; ok {
+			return entry
+		}
+	}
+
+	return nil
+}
+
+func (cache *fileCredentialCache) Set(registry string, entry *AuthEntry) {
+	registryCache, err := cache.load()
+	if err != nil {
+		registryCache = newRegistryCache()
+	}
+
+	key := cache.cachePrefixKey + registry
+	if entry.Service == ServiceECRPublic {
+		key = cache.publicCacheKey
+	}
+	registryCache.Registries[key] = entry
+
+	cache.save(registryCache)
+}
+
+func (cache *fileCredentialCache) GetPublic() *AuthEntry {
+	registryCache, err := cache.load()
+	if err != nil {
+		return nil
+	}
+
+	if entry, ok := registryCache.Registries[cache.publicCacheKey]; ok {
+		return entry
+	}
+
+	if !isFipsMode() && cache.legacyPublicCacheKey != "" {
+		if entry, ok := registryCache.Registries[cache.legacyPublicCacheKey]; ok {
+			return entry
+		}
+	}
+
+	return nil
+}
+
+func (cache *fileCredentialCache) Clear() {
+	os.Remove(cache.cacheFile())
+}
+
+func (cache *fileCredentialCache) List() []*AuthEntry {
+	registryCache, err := cache.load()
+	if err != nil {
+		return nil
+	}
+
+	entries := make([]*AuthEntry, 0, len(registryCache.Registries))
+	for _, entry := range registryCache.Registries {
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// Migrate rewrites entries stored under this cache's legacy MD5 keys to
+// their SHA-256 equivalents and removes the legacy entries, persisting the
+// result atomically. It is a no-op in FIPS mode, where legacy (MD5-backed)
+// keys are never read or written, and when there are no legacy keys to
+// migrate or this cache predates legacy keys entirely.
+func (cache *fileCredentialCache) Migrate() error {
+	if isFipsMode() {
+		return nil
+	}
+
+	registryCache, err := cache.load()
+	if err != nil {
+		return err
+	}
+
+	migrated := false
+	for key, entry := range registryCache.Registries {
+		newKey, ok := cache.migratedKey(key)
+		if !ok {
+			continue
+		}
+		if _, exists := registryCache.Registries[newKey]; !exists {
+			registryCache.Registries[newKey] = entry
+		}
+		delete(registryCache.Registries, key)
+		migrated = true
+	}
+
+	if !migrated {
+		return nil
+	}
+
+	return cache.saveAtomic(registryCache)
+}
+
+// migratedKey returns the current-format cache key that legacy key should be
+// rewritten to, and whether key is in fact one of this cache's legacy keys.
+func (cache *fileCredentialCache) migratedKey(key string) (string, bool) {
+	if cache.legacyCachePrefixKey != "" && strings.HasPrefix(key, cache.legacyCachePrefixKey) {
+		return cache.cachePrefixKey + strings.TrimPrefix(key, cache.legacyCachePrefixKey), true
+	}
+	if cache.legacyPublicCacheKey != "" && key == cache.legacyPublicCacheKey {
+		return cache.publicCacheKey, true
+	}
+	return "", false
+}
+
+func (cache *fileCredentialCache) cacheFile() string {
+	return filepath.Join(cache.path, cache.filename)
+}
+
+func (cache *fileCredentialCache) load() (*registryCache, error) {
+	data, err := os.ReadFile(cache.cacheFile())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return newRegistryCache(), nil
+		}
+		return nil, err
+	}
+
+	loaded := &registryCache{}
+	if err := json.Unmarshal(data, loaded); err != nil {
+		return nil, err
+	}
+
+	if loaded.Version != cacheVersion {
+		return newRegistryCache(), nil
+	}
+	if loaded.Registries == nil {
+		loaded.Registries = make(map[string]*AuthEntry)
+	}
+
+	return loaded, nil
+}
+
+func (cache *fileCredentialCache) save(registryCache *registryCache) error {
+	data, err := json.Marshal(registryCache)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(cache.path, 0700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(cache.cacheFile(), data, 0600)
+}
+
+// saveAtomic writes registryCache via a temporary file and rename, so a
+// process that crashes or is killed mid-write cannot leave the cache file
+// truncated or half-migrated.
+func (cache *fileCredentialCache) saveAtomic(registryCache *registryCache) error {
+	data, err := json.Marshal(registryCache)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(cache.path, 0700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(cache.path, "."+cache.filename+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmpName, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpName, cache.cacheFile())
+}
@@ -278,3 +278,88 @@ func TestFipsModeWithNewKey(t *testing.T) {
 	assert.NotNil(t, entry, "Should find credentials with SHA-256 key in FIPS mode")
 	assert.Equal(t, testAuthEntry.AuthorizationToken, entry.AuthorizationToken)
 }
+
+// TestMigrateRewritesLegacyKeysToNewKeys tests that Migrate rewrites entries
+// stored under legacy MD5 keys (both private and public) to their SHA-256
+// equivalents and removes the legacy entries.
+func TestMigrateRewritesLegacyKeysToNewKeys(t *testing.T) {
+	credentialCache := NewFileCredentialsCache(testPath, testFilename, testCachePrefixKey, testPublicCacheKey, testLegacyCachePrefixKey, testLegacyPublicCacheKey)
+	defer credentialCache.Clear()
+
+	registryCache := newRegistryCache()
+	legacyKey := testLegacyCachePrefixKey + testRegistryName
+	registryCache.Registries[legacyKey] = &testAuthEntry
+	registryCache.Registries[testLegacyPublicCacheKey] = &testPublicAuthEntry
+	credentialCache.(*fileCredentialCache).save(registryCache)
+
+	assert.NoError(t, credentialCache.Migrate())
+
+	loaded, err := credentialCache.(*fileCredentialCache).load()
+	assert.NoError(t, err)
+
+	newKey := testCachePrefixKey + testRegistryName
+	assert.NotNil(t, loaded.Registries[newKey])
+	assert.Equal(t, testAuthEntry.AuthorizationToken, loaded.Registries[newKey].AuthorizationToken)
+	assert.Nil(t, loaded.Registries[legacyKey], "legacy key should be removed after migration")
+
+	assert.NotNil(t, loaded.Registries[testPublicCacheKey])
+	assert.Nil(t, loaded.Registries[testLegacyPublicCacheKey], "legacy public key should be removed after migration")
+}
+
+// TestMigrateNewEntryWinsOnConflict tests that when both a legacy and a new
+// entry exist for the same registry, Migrate keeps the new entry and simply
+// discards the legacy one rather than overwriting it.
+func TestMigrateNewEntryWinsOnConflict(t *testing.T) {
+	credentialCache := NewFileCredentialsCache(testPath, testFilename, testCachePrefixKey, testPublicCacheKey, testLegacyCachePrefixKey, testLegacyPublicCacheKey)
+	defer credentialCache.Clear()
+
+	registryCache := newRegistryCache()
+	legacyKey := testLegacyCachePrefixKey + testRegistryName
+	newKey := testCachePrefixKey + testRegistryName
+
+	legacyEntry := testAuthEntry
+	legacyEntry.AuthorizationToken = "legacyToken"
+	newEntry := testAuthEntry
+	newEntry.AuthorizationToken = "newToken"
+
+	registryCache.Registries[legacyKey] = &legacyEntry
+	registryCache.Registries[newKey] = &newEntry
+	credentialCache.(*fileCredentialCache).save(registryCache)
+
+	assert.NoError(t, credentialCache.Migrate())
+
+	loaded, err := credentialCache.(*fileCredentialCache).load()
+	assert.NoError(t, err)
+	assert.Equal(t, "newToken", loaded.Registries[newKey].AuthorizationToken, "existing new entry should win over legacy on migration")
+	assert.Nil(t, loaded.Registries[legacyKey])
+}
+
+// TestMigrateIsNoOpInFipsMode tests that Migrate leaves legacy entries
+// untouched when running in FIPS mode.
+func TestMigrateIsNoOpInFipsMode(t *testing.T) {
+	os.Setenv("GODEBUG", "fips140=only")
+	defer os.Unsetenv("GODEBUG")
+
+	credentialCache := NewFileCredentialsCache(testPath, testFilename, testCachePrefixKey, testPublicCacheKey, testLegacyCachePrefixKey, testLegacyPublicCacheKey)
+	defer credentialCache.Clear()
+
+	registryCache := newRegistryCache()
+	legacyKey := testLegacyCachePrefixKey + testRegistryName
+	registryCache.Registries[legacyKey] = &testAuthEntry
+	credentialCache.(*fileCredentialCache).save(registryCache)
+
+	assert.NoError(t, credentialCache.Migrate())
+
+	loaded, err := credentialCache.(*fileCredentialCache).load()
+	assert.NoError(t, err)
+	assert.NotNil(t, loaded.Registries[legacyKey], "legacy entry must be untouched in FIPS mode")
+}
+
+// TestMigrateNoLegacyEntriesIsNoOp tests that Migrate is a harmless no-op
+// when the cache has no legacy entries to rewrite.
+func TestMigrateNoLegacyEntriesIsNoOp(t *testing.T) {
+	credentialCache := NewFileCredentialsCache(testPath, testFilename, testCachePrefixKey, testPublicCacheKey, testLegacyCachePrefixKey, testLegacyPublicCacheKey)
+	defer credentialCache.Clear()
+
+	assert.NoError(t, credentialCache.Migrate())
+}
@@ -0,0 +1,167 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cache
+
+import (
+	"encoding/json"
+
+	"github.com/99designs/keyring"
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// keyringServiceName namespaces this helper's entries within the OS
+// keychain/Secret Service/Windows Credential Manager from any other
+// application using the same keyring library.
+const keyringServiceName = "amazon-ecr-credential-helper"
+
+// keyringCredentialCache is a CredentialsCache backed by the host's native
+// secret store, so the plaintext ECR bearer token is never left sitting in
+// a file such as ~/.ecr/cache.json.
+type keyringCredentialCache struct {
+	ring                 keyring.Keyring
+	cachePrefixKey       string
+	publicCacheKey       string
+	legacyCachePrefixKey string
+	legacyPublicCacheKey string
+}
+
+// NewKeyringCredentialsCache opens the host keyring and returns a
+// CredentialsCache namespaced by the given keys, following the same
+// legacy-vs-current key semantics as NewFileCredentialsCache.
+func NewKeyringCredentialsCache(cachePrefixKey string, publicCacheKey string, legacyCachePrefixKey string, legacyPublicCacheKey string) (CredentialsCache, error) {
+	// Restricted to the platform-native secret stores: unlike the file
+	// backend, keyring's password-protected file fallback would block on an
+	// interactive passphrase prompt, which a credential helper invoked by
+	// docker must never do.
+	ring, err := keyring.Open(keyring.Config{
+		ServiceName: keyringServiceName,
+		AllowedBackends: []keyring.BackendType{
+			keyring.SecretServiceBackend,
+			keyring.KeychainBackend,
+			keyring.WinCredBackend,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &keyringCredentialCache{
+		ring:                 ring,
+		cachePrefixKey:       cachePrefixKey,
+		publicCacheKey:       publicCacheKey,
+		legacyCachePrefixKey: legacyCachePrefixKey,
+		legacyPublicCacheKey: legacyPublicCacheKey,
+	}, nil
+}
+
+func (cache *keyringCredentialCache) Get(registry string) *AuthEntry {
+	if entry, ok := cache.getKey(cache.cachePrefixKey + registry); ok {
+		return entry
+	}
+
+	if !isFipsMode() && cache.legacyCachePrefixKey != "" {
+		if entry, ok := cache.getKey(cache.legacyCachePrefixKey + registry); ok {
+			return entry
+		}
+	}
+
+	return nil
+}
+
+func (cache *keyringCredentialCache) Set(registry string, entry *AuthEntry) {
+	key := cache.cachePrefixKey + registry
+	if entry.Service == ServiceECRPublic {
+		key = cache.publicCacheKey
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	cache.ring.Set(keyring.Item{Key: key, Data: data})
+}
+
+func (cache *keyringCredentialCache) GetPublic() *AuthEntry {
+	if entry, ok := cache.getKey(cache.publicCacheKey); ok {
+		return entry
+	}
+
+	if !isFipsMode() && cache.legacyPublicCacheKey != "" {
+		if entry, ok := cache.getKey(cache.legacyPublicCacheKey); ok {
+			return entry
+		}
+	}
+
+	return nil
+}
+
+func (cache *keyringCredentialCache) Clear() {
+	keys, err := cache.ring.Keys()
+	if err != nil {
+		return
+	}
+
+	for _, key := range keys {
+		cache.ring.Remove(key)
+	}
+}
+
+func (cache *keyringCredentialCache) List() []*AuthEntry {
+	keys, err := cache.ring.Keys()
+	if err != nil {
+		return nil
+	}
+
+	entries := make([]*AuthEntry, 0, len(keys))
+	for _, key := range keys {
+		if entry, ok := cache.getKey(key); ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func (cache *keyringCredentialCache) getKey(key string) (*AuthEntry, bool) {
+	item, err := cache.ring.Get(key)
+	if err != nil {
+		return nil, false
+	}
+
+	entry := &AuthEntry{}
+	if err := json.Unmarshal(item.Data, entry); err != nil {
+		return nil, false
+	}
+
+	return entry, true
+}
+
+// Migrate is a no-op for the keyring backend: entries stored under legacy
+// keys are left in place, and outside FIPS mode Get and GetPublic still find
+// them through the legacy-key fallback.
+func (cache *keyringCredentialCache) Migrate() error { return nil }
+
+func newKeyringCacheBackend(config aws.Config, cacheDir string) CredentialsCache {
+	keys, _, ok := resolveCacheKeys(config)
+	if !ok {
+		return &nullCredentialsCache{}
+	}
+
+	ring, err := NewKeyringCredentialsCache(keys.cachePrefixKey, keys.publicCacheKey, keys.legacyCachePrefixKey, keys.legacyPublicCacheKey)
+	if err != nil {
+		return &nullCredentialsCache{}
+	}
+
+	return ring
+}
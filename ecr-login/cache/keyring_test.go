@@ -0,0 +1,87 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cache
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/99designs/keyring"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestKeyringCache builds a keyringCredentialCache backed by keyring's
+// in-memory ArrayKeyring, so these tests do not touch a real OS keychain.
+func newTestKeyringCache(cachePrefixKey string, publicCacheKey string, legacyCachePrefixKey string, legacyPublicCacheKey string) *keyringCredentialCache {
+	return &keyringCredentialCache{
+		ring:                 keyring.NewArrayKeyring(nil),
+		cachePrefixKey:       cachePrefixKey,
+		publicCacheKey:       publicCacheKey,
+		legacyCachePrefixKey: legacyCachePrefixKey,
+		legacyPublicCacheKey: legacyPublicCacheKey,
+	}
+}
+
+func setTestKeyringEntry(t *testing.T, credentialCache *keyringCredentialCache, key string, entry *AuthEntry) {
+	t.Helper()
+	data, err := json.Marshal(entry)
+	assert.NoError(t, err)
+	assert.NoError(t, credentialCache.ring.Set(keyring.Item{Key: key, Data: data}))
+}
+
+func TestKeyringCredentials(t *testing.T) {
+	credentialCache := newTestKeyringCache(testCachePrefixKey, testPublicCacheKey, testLegacyCachePrefixKey, testLegacyPublicCacheKey)
+
+	credentialCache.Set(testRegistryName, &testAuthEntry)
+
+	entry := credentialCache.Get(testRegistryName)
+	assert.Equal(t, testAuthEntry.AuthorizationToken, entry.AuthorizationToken)
+
+	entries := credentialCache.List()
+	assert.Len(t, entries, 1)
+
+	credentialCache.Clear()
+	assert.Nil(t, credentialCache.Get(testRegistryName))
+}
+
+// TestKeyringNewKeyPreferredOverLegacy mirrors TestNewKeyPreferredOverLegacy
+// in file_test.go to ensure the keyring backend honors the same legacy-vs-new
+// key precedence as the file backend.
+func TestKeyringNewKeyPreferredOverLegacy(t *testing.T) {
+	credentialCache := newTestKeyringCache(testCachePrefixKey, testPublicCacheKey, testLegacyCachePrefixKey, testLegacyPublicCacheKey)
+
+	legacyEntry := testAuthEntry
+	legacyEntry.AuthorizationToken = "legacyToken"
+	newEntry := testAuthEntry
+	newEntry.AuthorizationToken = "newToken"
+
+	setTestKeyringEntry(t, credentialCache, testLegacyCachePrefixKey+testRegistryName, &legacyEntry)
+	setTestKeyringEntry(t, credentialCache, testCachePrefixKey+testRegistryName, &newEntry)
+
+	entry := credentialCache.Get(testRegistryName)
+	assert.Equal(t, "newToken", entry.AuthorizationToken, "Should prefer new key over legacy key")
+}
+
+// TestKeyringFipsModeOnlySkipsLegacyLookup mirrors TestFipsModeOnlySkipsLegacyLookup
+// in file_test.go for the keyring backend.
+func TestKeyringFipsModeOnlySkipsLegacyLookup(t *testing.T) {
+	os.Setenv("GODEBUG", "fips140=only")
+	defer os.Unsetenv("GODEBUG")
+
+	credentialCache := newTestKeyringCache(testCachePrefixKey, testPublicCacheKey, testLegacyCachePrefixKey, testLegacyPublicCacheKey)
+	setTestKeyringEntry(t, credentialCache, testLegacyCachePrefixKey+testRegistryName, &testAuthEntry)
+
+	assert.Nil(t, credentialCache.Get(testRegistryName), "Should return nil in FIPS mode when only legacy key exists")
+}
@@ -0,0 +1,121 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cache
+
+import (
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// memoryCredentialCache is a CredentialsCache that never touches disk,
+// intended for short-lived CLI invocations and CI where a cache file would
+// just be dead weight. It only helps within a single process lifetime.
+type memoryCredentialCache struct {
+	mu                   sync.Mutex
+	cachePrefixKey       string
+	publicCacheKey       string
+	legacyCachePrefixKey string
+	legacyPublicCacheKey string
+	entries              map[string]*AuthEntry
+}
+
+// NewMemoryCredentialsCache creates an in-memory CredentialsCache namespaced
+// by the given keys, following the same legacy-vs-current key semantics as
+// NewFileCredentialsCache.
+func NewMemoryCredentialsCache(cachePrefixKey string, publicCacheKey string, legacyCachePrefixKey string, legacyPublicCacheKey string) CredentialsCache {
+	return &memoryCredentialCache{
+		cachePrefixKey:       cachePrefixKey,
+		publicCacheKey:       publicCacheKey,
+		legacyCachePrefixKey: legacyCachePrefixKey,
+		legacyPublicCacheKey: legacyPublicCacheKey,
+		entries:              make(map[string]*AuthEntry),
+	}
+}
+
+func (cache *memoryCredentialCache) Get(registry string) *AuthEntry {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if entry, ok := cache.entries[cache.cachePrefixKey+registry]; ok {
+		return entry
+	}
+
+	if !isFipsMode() && cache.legacyCachePrefixKey != "" {
+		if entry, ok := cache.entries[cache.legacyCachePrefixKey+registry]; ok {
+			return entry
+		}
+	}
+
+	return nil
+}
+
+func (cache *memoryCredentialCache) Set(registry string, entry *AuthEntry) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	key := cache.cachePrefixKey + registry
+	if entry.Service == ServiceECRPublic {
+		key = cache.publicCacheKey
+	}
+	cache.entries[key] = entry
+}
+
+func (cache *memoryCredentialCache) GetPublic() *AuthEntry {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if entry, ok := cache.entries[cache.publicCacheKey]; ok {
+		return entry
+	}
+
+	if !isFipsMode() && cache.legacyPublicCacheKey != "" {
+		if entry, ok := cache.entries[cache.legacyPublicCacheKey]; ok {
+			return entry
+		}
+	}
+
+	return nil
+}
+
+func (cache *memoryCredentialCache) Clear() {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.entries = make(map[string]*AuthEntry)
+}
+
+func (cache *memoryCredentialCache) List() []*AuthEntry {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	entries := make([]*AuthEntry, 0, len(cache.entries))
+	for _, entry := range cache.entries {
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// Migrate is a no-op: a fresh in-memory cache never has legacy entries
+// carried over from a previous process, so there is nothing to rewrite.
+func (cache *memoryCredentialCache) Migrate() error { return nil }
+
+func newMemoryCacheBackend(config aws.Config, cacheDir string) CredentialsCache {
+	keys, _, ok := resolveCacheKeys(config)
+	if !ok {
+		return &nullCredentialsCache{}
+	}
+
+	return NewMemoryCredentialsCache(keys.cachePrefixKey, keys.publicCacheKey, keys.legacyCachePrefixKey, keys.legacyPublicCacheKey)
+}
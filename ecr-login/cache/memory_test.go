@@ -0,0 +1,75 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cache
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryCredentials(t *testing.T) {
+	credentialCache := NewMemoryCredentialsCache(testCachePrefixKey, testPublicCacheKey, testLegacyCachePrefixKey, testLegacyPublicCacheKey)
+
+	credentialCache.Set(testRegistryName, &testAuthEntry)
+
+	entry := credentialCache.Get(testRegistryName)
+	assert.Equal(t, testAuthEntry.AuthorizationToken, entry.AuthorizationToken)
+
+	entries := credentialCache.List()
+	assert.Len(t, entries, 1)
+
+	credentialCache.Clear()
+	assert.Nil(t, credentialCache.Get(testRegistryName))
+}
+
+func TestMemoryCredentialsPublic(t *testing.T) {
+	credentialCache := NewMemoryCredentialsCache(testCachePrefixKey, testPublicCacheKey, testLegacyCachePrefixKey, testLegacyPublicCacheKey)
+
+	credentialCache.Set(testRegistryName, &testPublicAuthEntry)
+
+	entry := credentialCache.GetPublic()
+	assert.Equal(t, testPublicAuthEntry.AuthorizationToken, entry.AuthorizationToken)
+}
+
+// TestMemoryNewKeyPreferredOverLegacy mirrors TestNewKeyPreferredOverLegacy
+// in file_test.go to ensure the memory backend honors the same legacy-vs-new
+// key precedence as the file backend.
+func TestMemoryNewKeyPreferredOverLegacy(t *testing.T) {
+	credentialCache := NewMemoryCredentialsCache(testCachePrefixKey, testPublicCacheKey, testLegacyCachePrefixKey, testLegacyPublicCacheKey).(*memoryCredentialCache)
+
+	legacyEntry := testAuthEntry
+	legacyEntry.AuthorizationToken = "legacyToken"
+	newEntry := testAuthEntry
+	newEntry.AuthorizationToken = "newToken"
+
+	credentialCache.entries[testLegacyCachePrefixKey+testRegistryName] = &legacyEntry
+	credentialCache.entries[testCachePrefixKey+testRegistryName] = &newEntry
+
+	entry := credentialCache.Get(testRegistryName)
+	assert.Equal(t, "newToken", entry.AuthorizationToken, "Should prefer new key over legacy key")
+}
+
+// TestMemoryFipsModeOnlySkipsLegacyLookup mirrors TestFipsModeOnlySkipsLegacyLookup
+// in file_test.go for the memory backend.
+func TestMemoryFipsModeOnlySkipsLegacyLookup(t *testing.T) {
+	os.Setenv("GODEBUG", "fips140=only")
+	defer os.Unsetenv("GODEBUG")
+
+	credentialCache := NewMemoryCredentialsCache(testCachePrefixKey, testPublicCacheKey, testLegacyCachePrefixKey, testLegacyPublicCacheKey).(*memoryCredentialCache)
+	credentialCache.entries[testLegacyCachePrefixKey+testRegistryName] = &testAuthEntry
+
+	assert.Nil(t, credentialCache.Get(testRegistryName), "Should return nil in FIPS mode when only legacy key exists")
+}